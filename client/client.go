@@ -0,0 +1,98 @@
+// Package client talks to the Forgejo runner service on behalf of a single
+// runner. The wire contract is defined in proto/runner/v1/runner.proto and
+// implemented over Connect/gRPC by New; FakeClient provides an in-memory
+// implementation for tests that never touch the network.
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"time"
+)
+
+// StageStatus reports the outcome of a Stage back to the server.
+type StageStatus int
+
+const (
+	StatusRunning StageStatus = iota
+	StatusSuccess
+	StatusFailure
+)
+
+func (s StageStatus) String() string {
+	switch s {
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusFailure:
+		return "failure"
+	default:
+		return "unknown"
+	}
+}
+
+// Step is a single action within a Stage.
+type Step struct {
+	Name string
+	Run  string
+}
+
+// Stage is a unit of work handed to a runner by the server: a task along
+// with the steps, environment, secrets and labels needed to execute it. It
+// replaces the old MsgTypeRequestBuild payload.
+type Stage struct {
+	BuildUUID    string
+	Repo         string
+	EventName    string
+	Steps        []Step
+	Env          map[string]string
+	Secrets      map[string]string
+	Labels       []string
+	WorkflowYAML []byte
+	WorkflowSig  []byte
+}
+
+// LogRow is a single timestamped line of step output.
+type LogRow struct {
+	Time    time.Time
+	Content string
+}
+
+// Client is the runner's view of the Forgejo runner service.
+type Client interface {
+	// Register announces this runner to the server and returns the
+	// runner UUID it has been assigned.
+	Register(ctx context.Context, name string, labels []string) (runnerUUID string, err error)
+
+	// Request blocks (long-poll) until a Stage is available for one of
+	// labels, or ctx is cancelled. Passing labels lets the server avoid
+	// offering a Stage this runner would just Nack.
+	Request(ctx context.Context, labels []string) (*Stage, error)
+
+	// Update reports the current status of a Stage.
+	Update(ctx context.Context, buildUUID string, status StageStatus, errContent string) error
+
+	// Log appends rows to the log of a running Stage.
+	Log(ctx context.Context, buildUUID string, rows []LogRow) error
+
+	// Nack rejects a Stage this runner cannot execute, e.g. because none
+	// of its labels match, so the server can offer it to another runner.
+	Nack(ctx context.Context, buildUUID string, reason string) error
+
+	// Ping checks connectivity and that the runner is still known to
+	// the server.
+	Ping(ctx context.Context) error
+
+	// SigningKey returns the repo-scoped ed25519 public key the Forgejo
+	// instance signs repo's workflow files with, for use with
+	// secure.Verify.
+	SigningKey(ctx context.Context, repo string) (ed25519.PublicKey, error)
+}
+
+// New builds a Client backed by a Connect/gRPC transport talking to the
+// Forgejo runner service at address. token authenticates the runner;
+// uuid is empty until the first successful Register call.
+func New(address string, insecure bool, uuid, token, version string) Client {
+	return newConnectClient(address, insecure, uuid, token, version)
+}
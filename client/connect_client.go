@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"net/http"
+	"sync"
+
+	"connectrpc.com/connect"
+
+	runnerv1 "codeberg.org/forgejo/runner/pkg/runnerv1"
+	"codeberg.org/forgejo/runner/pkg/runnerv1/runnerv1connect"
+)
+
+// connectClient implements Client over the Connect protocol, which speaks
+// both gRPC and plain HTTP/JSON against the same RunnerService defined in
+// proto/runner/v1/runner.proto.
+type connectClient struct {
+	address string
+	token   string
+	version string
+
+	svc runnerv1connect.RunnerServiceClient
+
+	mu   sync.RWMutex
+	uuid string
+}
+
+func newConnectClient(address string, insecure bool, uuid, token, version string) *connectClient {
+	httpClient := http.DefaultClient
+	if insecure {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // explicit opt-in via --insecure
+			},
+		}
+	}
+
+	return &connectClient{
+		address: address,
+		token:   token,
+		version: version,
+		uuid:    uuid,
+		svc:     runnerv1connect.NewRunnerServiceClient(httpClient, address),
+	}
+}
+
+func (c *connectClient) authenticated(req interface{ Header() http.Header }) {
+	req.Header().Set("Authorization", "Bearer "+c.token)
+}
+
+func (c *connectClient) runnerUUID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.uuid
+}
+
+func (c *connectClient) Register(ctx context.Context, name string, labels []string) (string, error) {
+	req := connect.NewRequest(&runnerv1.RegisterRequest{
+		Token:   c.token,
+		Name:    name,
+		Labels:  labels,
+		Version: c.version,
+	})
+	c.authenticated(req)
+
+	resp, err := c.svc.Register(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.uuid = resp.Msg.RunnerUuid
+	c.mu.Unlock()
+
+	return resp.Msg.RunnerUuid, nil
+}
+
+func (c *connectClient) Request(ctx context.Context, labels []string) (*Stage, error) {
+	req := connect.NewRequest(&runnerv1.FetchTaskRequest{
+		RunnerUuid: c.runnerUUID(),
+		Labels:     labels,
+	})
+	c.authenticated(req)
+
+	resp, err := c.svc.FetchTask(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Msg.Stage == nil {
+		return nil, nil
+	}
+
+	pb := resp.Msg.Stage
+	steps := make([]Step, 0, len(pb.Steps))
+	for _, s := range pb.Steps {
+		steps = append(steps, Step{Name: s.Name, Run: s.Run})
+	}
+
+	return &Stage{
+		BuildUUID:    pb.BuildUuid,
+		Repo:         pb.Repo,
+		EventName:    pb.EventName,
+		Steps:        steps,
+		Env:          pb.Env,
+		Secrets:      pb.Secrets,
+		Labels:       pb.Labels,
+		WorkflowYAML: pb.WorkflowYaml,
+		WorkflowSig:  pb.WorkflowSig,
+	}, nil
+}
+
+func (c *connectClient) Update(ctx context.Context, buildUUID string, status StageStatus, errContent string) error {
+	req := connect.NewRequest(&runnerv1.UpdateTaskRequest{
+		BuildUuid:  buildUUID,
+		Status:     toProtoStatus(status),
+		ErrContent: errContent,
+	})
+	c.authenticated(req)
+
+	_, err := c.svc.UpdateTask(ctx, req)
+	return err
+}
+
+func (c *connectClient) Log(ctx context.Context, buildUUID string, rows []LogRow) error {
+	pbRows := make([]*runnerv1.LogRow, 0, len(rows))
+	for _, r := range rows {
+		pbRows = append(pbRows, &runnerv1.LogRow{
+			TimestampUnixNano: r.Time.UnixNano(),
+			Content:           r.Content,
+		})
+	}
+
+	req := connect.NewRequest(&runnerv1.UpdateLogRequest{
+		BuildUuid: buildUUID,
+		Rows:      pbRows,
+	})
+	c.authenticated(req)
+
+	_, err := c.svc.UpdateLog(ctx, req)
+	return err
+}
+
+// toProtoStatus maps a StageStatus to its runnerv1 wire value explicitly:
+// the proto enum reserves 0 for STAGE_STATUS_UNSPECIFIED, so it does not
+// line up with StageStatus's own iota and must never be cast directly.
+func toProtoStatus(status StageStatus) runnerv1.StageStatus {
+	switch status {
+	case StatusRunning:
+		return runnerv1.StageStatus_STAGE_STATUS_RUNNING
+	case StatusSuccess:
+		return runnerv1.StageStatus_STAGE_STATUS_SUCCESS
+	case StatusFailure:
+		return runnerv1.StageStatus_STAGE_STATUS_FAILURE
+	default:
+		return runnerv1.StageStatus_STAGE_STATUS_UNSPECIFIED
+	}
+}
+
+func (c *connectClient) Nack(ctx context.Context, buildUUID string, reason string) error {
+	req := connect.NewRequest(&runnerv1.NackTaskRequest{
+		BuildUuid: buildUUID,
+		Reason:    reason,
+	})
+	c.authenticated(req)
+
+	_, err := c.svc.NackTask(ctx, req)
+	return err
+}
+
+func (c *connectClient) Ping(ctx context.Context) error {
+	req := connect.NewRequest(&runnerv1.PingRequest{
+		RunnerUuid: c.runnerUUID(),
+	})
+	c.authenticated(req)
+
+	_, err := c.svc.Ping(ctx, req)
+	return err
+}
+
+func (c *connectClient) SigningKey(ctx context.Context, repo string) (ed25519.PublicKey, error) {
+	req := connect.NewRequest(&runnerv1.SigningKeyRequest{Repo: repo})
+	c.authenticated(req)
+
+	resp, err := c.svc.SigningKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return ed25519.PublicKey(resp.Msg.PublicKey), nil
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"testing"
+
+	runnerv1 "codeberg.org/forgejo/runner/pkg/runnerv1"
+)
+
+func TestToProtoStatus(t *testing.T) {
+	cases := []struct {
+		status StageStatus
+		want   runnerv1.StageStatus
+	}{
+		{StatusRunning, runnerv1.StageStatus_STAGE_STATUS_RUNNING},
+		{StatusSuccess, runnerv1.StageStatus_STAGE_STATUS_SUCCESS},
+		{StatusFailure, runnerv1.StageStatus_STAGE_STATUS_FAILURE},
+	}
+
+	for _, c := range cases {
+		if got := toProtoStatus(c.status); got != c.want {
+			t.Errorf("toProtoStatus(%v) = %v, want %v", c.status, got, c.want)
+		}
+	}
+}
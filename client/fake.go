@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+)
+
+// FakeClient is an in-memory Client used to unit-test poller and runtime
+// without a network. Tests push work with Push and inspect reported
+// outcomes via Updates/Logs.
+type FakeClient struct {
+	mu      sync.Mutex
+	stages  chan *Stage
+	Updates []FakeUpdate
+	Logs    []FakeLog
+	Nacks   []FakeNack
+
+	RunnerUUID string
+
+	// SigningKeys, keyed by repo, backs SigningKey for tests exercising
+	// signed-workflow verification.
+	SigningKeys map[string]ed25519.PublicKey
+}
+
+// FakeUpdate records a call to Update.
+type FakeUpdate struct {
+	BuildUUID  string
+	Status     StageStatus
+	ErrContent string
+}
+
+// FakeLog records a call to Log.
+type FakeLog struct {
+	BuildUUID string
+	Rows      []LogRow
+}
+
+// FakeNack records a call to Nack.
+type FakeNack struct {
+	BuildUUID string
+	Reason    string
+}
+
+// NewFake returns a ready-to-use FakeClient.
+func NewFake() *FakeClient {
+	return &FakeClient{
+		stages: make(chan *Stage, 16),
+	}
+}
+
+// Push queues a Stage to be returned by the next Request call.
+func (f *FakeClient) Push(stage *Stage) {
+	f.stages <- stage
+}
+
+func (f *FakeClient) Register(_ context.Context, _ string, _ []string) (string, error) {
+	if f.RunnerUUID == "" {
+		f.RunnerUUID = "fake-runner-uuid"
+	}
+	return f.RunnerUUID, nil
+}
+
+func (f *FakeClient) Request(ctx context.Context, _ []string) (*Stage, error) {
+	select {
+	case stage := <-f.stages:
+		return stage, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *FakeClient) Update(_ context.Context, buildUUID string, status StageStatus, errContent string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Updates = append(f.Updates, FakeUpdate{BuildUUID: buildUUID, Status: status, ErrContent: errContent})
+	return nil
+}
+
+func (f *FakeClient) Log(_ context.Context, buildUUID string, rows []LogRow) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Logs = append(f.Logs, FakeLog{BuildUUID: buildUUID, Rows: rows})
+	return nil
+}
+
+func (f *FakeClient) Nack(_ context.Context, buildUUID string, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Nacks = append(f.Nacks, FakeNack{BuildUUID: buildUUID, Reason: reason})
+	return nil
+}
+
+func (f *FakeClient) Ping(_ context.Context) error {
+	return nil
+}
+
+func (f *FakeClient) SigningKey(_ context.Context, repo string) (ed25519.PublicKey, error) {
+	key, ok := f.SigningKeys[repo]
+	if !ok {
+		return nil, fmt.Errorf("fake client: no signing key registered for repo %q", repo)
+	}
+	return key, nil
+}
+
+var _ Client = (*FakeClient)(nil)
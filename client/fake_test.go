@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFakeClientRequestReturnsPushedStage(t *testing.T) {
+	fc := NewFake()
+	fc.Push(&Stage{BuildUUID: "build-1", Labels: []string{"ubuntu-latest"}})
+
+	stage, err := fc.Request(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	if stage.BuildUUID != "build-1" {
+		t.Errorf("Request() build UUID = %q, want %q", stage.BuildUUID, "build-1")
+	}
+}
+
+func TestFakeClientRecordsUpdates(t *testing.T) {
+	fc := NewFake()
+	if err := fc.Update(context.Background(), "build-1", StatusSuccess, ""); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if len(fc.Updates) != 1 || fc.Updates[0].Status != StatusSuccess {
+		t.Errorf("Updates = %+v, want one success update for build-1", fc.Updates)
+	}
+}
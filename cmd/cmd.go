@@ -18,6 +18,7 @@ func Execute(ctx context.Context) {
 	// task := runtime.NewTask("gitea", 0, nil, nil)
 
 	var gArgs globalArgs
+	var dArgs daemonArgs
 
 	// ./act_runner
 	rootCmd := &cobra.Command{
@@ -26,7 +27,7 @@ func Execute(ctx context.Context) {
 		Args:         cobra.MaximumNArgs(1),
 		Version:      version,
 		SilenceUsage: true,
-		RunE:         runDaemon(ctx, gArgs.EnvFile),
+		RunE:         runDaemon(ctx, &dArgs, gArgs.EnvFile),
 	}
 	rootCmd.PersistentFlags().StringVarP(&gArgs.EnvFile, "env-file", "", ".env", "Read in a file of environment variables.")
 
@@ -44,6 +45,8 @@ func Execute(ctx context.Context) {
 	registerCmd.Flags().StringVar(&regArgs.Token, "token", "", "Runner token")
 	registerCmd.Flags().StringVar(&regArgs.RunnerName, "name", "", "Runner name")
 	registerCmd.Flags().StringVar(&regArgs.Labels, "labels", "", "Runner tags, comma separated")
+	registerCmd.Flags().BoolVar(&regArgs.RequireSignedWorkflows, "require-signed-workflows", false, "Refuse to run workflows that aren't signed by a trusted key")
+	registerCmd.Flags().StringVar(&regArgs.TrustedKeysFile, "trusted-keys-file", "", "PEM file of ed25519 public keys trusted to sign workflows")
 	rootCmd.AddCommand(registerCmd)
 
 	// ./act_runner daemon
@@ -51,8 +54,9 @@ func Execute(ctx context.Context) {
 		Use:   "daemon",
 		Short: "Run as a runner daemon",
 		Args:  cobra.MaximumNArgs(1),
-		RunE:  runDaemon(ctx, gArgs.EnvFile),
+		RunE:  runDaemon(ctx, &dArgs, gArgs.EnvFile),
 	}
+	daemonCmd.Flags().StringVar(&dArgs.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics and pprof on this address, e.g. :9090")
 	rootCmd.AddCommand(daemonCmd)
 
 	// ./act_runner exec
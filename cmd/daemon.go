@@ -2,15 +2,23 @@ package cmd
 
 import (
 	"context"
+	"crypto/ed25519"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"codeberg.org/forgejo/runner/artifactcache"
 	"codeberg.org/forgejo/runner/client"
 	"codeberg.org/forgejo/runner/config"
 	"codeberg.org/forgejo/runner/engine"
+	"codeberg.org/forgejo/runner/metrics"
 	"codeberg.org/forgejo/runner/poller"
 	"codeberg.org/forgejo/runner/runtime"
+	"codeberg.org/forgejo/runner/secure"
 
 	"github.com/joho/godotenv"
 	"github.com/mattn/go-isatty"
@@ -19,7 +27,12 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func runDaemon(ctx context.Context, envFile string) func(cmd *cobra.Command, args []string) error {
+// daemonArgs holds the flags specific to the `daemon` command.
+type daemonArgs struct {
+	MetricsAddr string
+}
+
+func runDaemon(ctx context.Context, dArgs *daemonArgs, envFile string) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		log.Infoln("Starting runner daemon")
 
@@ -29,25 +42,21 @@ func runDaemon(ctx context.Context, envFile string) func(cmd *cobra.Command, arg
 			log.WithError(err).
 				Fatalln("invalid configuration")
 		}
+		if dArgs.MetricsAddr != "" {
+			cfg.Runner.MetricsAddr = dArgs.MetricsAddr
+		}
 
 		initLogging(cfg)
 
 		// require docker if a runner label uses a docker backend
-		needsDocker := false
-		for _, l := range cfg.Runner.Labels {
-			splits := strings.SplitN(l, ":", 2)
-			if len(splits) == 2 && strings.HasPrefix(splits[1], "docker://") {
-				needsDocker = true
-				break
-			}
-		}
-
-		if needsDocker {
+		if needsDocker(cfg.Runner.Labels) {
 			// try to connect to docker daemon
 			// if failed, exit with error
 			if err := engine.Start(ctx); err != nil {
+				metrics.DockerReachable.Set(0)
 				log.WithError(err).Fatalln("failed to connect docker daemon engine")
 			}
+			metrics.DockerReachable.Set(1)
 		}
 
 		handler, err := artifactcache.NewHandler()
@@ -66,21 +75,55 @@ func runDaemon(ctx context.Context, envFile string) func(cmd *cobra.Command, arg
 			version,
 		)
 
+		var trustedKeys []ed25519.PublicKey
+		if cfg.Runner.TrustedKeysFile != "" {
+			trustedKeys, err = secure.LoadTrustedKeys(cfg.Runner.TrustedKeysFile)
+			if err != nil {
+				log.WithError(err).Fatalln("failed to load trusted keys file")
+			}
+		}
+
 		runner := &runtime.Runner{
-			Client:        cli,
-			Machine:       cfg.Runner.Name,
-			ForgeInstance: cfg.Client.Address,
-			Environ:       cfg.Runner.Environ,
-			Labels:        cfg.Runner.Labels,
-			Version:       version,
-			CacheHandler:  handler,
+			Client:                 cli,
+			Machine:                cfg.Runner.Name,
+			ForgeInstance:          cfg.Client.Address,
+			Environ:                cfg.Runner.Environ,
+			Labels:                 cfg.Runner.Labels,
+			Version:                version,
+			CacheHandler:           handler,
+			RequireSignedWorkflows: cfg.Runner.RequireSignedWorkflows,
+			TrustedKeys:            trustedKeys,
 		}
 
-		poller := poller.New(
+		poll := poller.New(
 			cli,
 			runner.Run,
 			cfg.Runner.Capacity,
+			cfg.Runner.Labels,
 		)
+		runner.LogSink = poll.RecordLog
+
+		// shutdownTimeout is read by the polling goroutine below and
+		// updated on SIGHUP, so it's kept behind an atomic rather than
+		// read directly off cfg.
+		var shutdownTimeout atomic.Int64
+		shutdownTimeout.Store(int64(cfg.Runner.ShutdownTimeout))
+
+		runCtx, stop := context.WithCancel(ctx)
+
+		if cfg.Runner.StatusAddr != "" {
+			runHTTPServer(&g, runCtx, "worker status", cfg.Runner.StatusAddr, poll)
+		}
+
+		if cfg.Runner.MetricsAddr != "" {
+			runHTTPServer(&g, runCtx, "metrics and pprof", cfg.Runner.MetricsAddr, metrics.Handler())
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		g.Go(func() error {
+			return handleSignals(runCtx, stop, sigs, envFile, runner, poll, &shutdownTimeout)
+		})
 
 		g.Go(func() error {
 			l := log.WithField("capacity", cfg.Runner.Capacity).
@@ -89,10 +132,11 @@ func runDaemon(ctx context.Context, envFile string) func(cmd *cobra.Command, arg
 				WithField("arch", cfg.Platform.Arch)
 			l.Infoln("polling the remote server")
 
-			if err := poller.Poll(ctx); err != nil {
+			if err := poll.Poll(runCtx); err != nil && runCtx.Err() == nil {
 				l.Errorf("poller error: %v", err)
 			}
-			poller.Wait()
+
+			poll.Shutdown(time.Duration(shutdownTimeout.Load()))
 			return nil
 		})
 
@@ -105,6 +149,96 @@ func runDaemon(ctx context.Context, envFile string) func(cmd *cobra.Command, arg
 	}
 }
 
+// runHTTPServer starts an http.Server serving handler on addr under g, and
+// shuts it down once ctx is cancelled. Without this, g.Wait() in runDaemon
+// would block forever on a bare ListenAndServe goroutine after a shutdown
+// signal, since nothing ever tells the listener to stop.
+func runHTTPServer(g *errgroup.Group, ctx context.Context, name, addr string, handler http.Handler) {
+	srv := &http.Server{Addr: addr, Handler: handler}
+
+	g.Go(func() error {
+		log.WithField("address", addr).Infof("serving %s", name)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		<-ctx.Done()
+		return srv.Shutdown(context.Background())
+	})
+}
+
+// handleSignals reacts to SIGHUP by reloading envFile and re-registering
+// with the (possibly changed) labels, and to SIGINT/SIGTERM by stopping
+// the poller from accepting new work; the poll goroutine then drains
+// in-flight jobs itself via poll.Shutdown.
+func handleSignals(
+	ctx context.Context,
+	stop context.CancelFunc,
+	sigs chan os.Signal,
+	envFile string,
+	runner *runtime.Runner,
+	poll *poller.Poller,
+	shutdownTimeout *atomic.Int64,
+) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-sigs:
+			switch sig {
+			case syscall.SIGHUP:
+				reload(ctx, envFile, runner, poll, shutdownTimeout)
+			case syscall.SIGINT, syscall.SIGTERM:
+				log.Infoln("received shutdown signal, draining in-flight jobs")
+				stop()
+				return nil
+			}
+		}
+	}
+}
+
+// reload re-reads envFile and applies what can change without a restart:
+// runner labels/environ and the shutdown grace period. It then
+// re-registers with the server so its label set is up to date. Capacity
+// changes require a restart, since the poller's worker pool is fixed size.
+func reload(ctx context.Context, envFile string, runner *runtime.Runner, poll *poller.Poller, shutdownTimeout *atomic.Int64) {
+	log.Infoln("received SIGHUP, reloading configuration")
+
+	if err := godotenv.Overload(envFile); err != nil {
+		log.WithError(err).Error("failed to read env file, keeping previous configuration")
+		return
+	}
+
+	cfg, err := config.FromEnviron()
+	if err != nil {
+		log.WithError(err).Error("invalid configuration, keeping previous configuration")
+		return
+	}
+
+	runner.Labels = cfg.Runner.Labels
+	runner.Environ = cfg.Runner.Environ
+	poll.SetLabels(cfg.Runner.Labels)
+	shutdownTimeout.Store(int64(cfg.Runner.ShutdownTimeout))
+
+	if _, err := runner.Client.Register(ctx, runner.Machine, cfg.Runner.Labels); err != nil {
+		log.WithError(err).Error("failed to re-register with new labels")
+	}
+}
+
+// needsDocker reports whether any label uses a docker:// backend.
+func needsDocker(labels []string) bool {
+	for _, l := range labels {
+		splits := strings.SplitN(l, ":", 2)
+		if len(splits) == 2 && strings.HasPrefix(splits[1], "docker://") {
+			return true
+		}
+	}
+	return false
+}
+
 // initLogging setup the global logrus logger.
 func initLogging(cfg config.Config) {
 	isTerm := isatty.IsTerminal(os.Stdout.Fd())
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"codeberg.org/forgejo/runner/client"
+	"codeberg.org/forgejo/runner/secure"
+
+	"github.com/joho/godotenv"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// registerArgs holds the `act_runner register` flags.
+type registerArgs struct {
+	NoInteractive bool
+	InstanceAddr  string
+	Insecure      bool
+	Token         string
+	RunnerName    string
+	Labels        string
+
+	// RequireSignedWorkflows and TrustedKeysFile configure the signed-
+	// workflow verification in runtime.Runner (see package secure); they
+	// are written to envFile alongside the registration itself.
+	RequireSignedWorkflows bool
+	TrustedKeysFile        string
+}
+
+// validateLabels checks that each label is either a bare tag (e.g.
+// "self-hosted") or a "name:docker://image" pair understood by the Docker
+// execution backend.
+func validateLabels(labels []string) error {
+	for _, l := range labels {
+		splits := strings.SplitN(l, ":", 2)
+		if len(splits) == 2 && !strings.HasPrefix(splits[1], "docker://") {
+			return fmt.Errorf("invalid label %q: expected a docker:// image reference after ':'", l)
+		}
+	}
+	return nil
+}
+
+func runRegister(ctx context.Context, regArgs *registerArgs, envFile string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		reader := bufio.NewReader(os.Stdin)
+
+		if !regArgs.NoInteractive {
+			if regArgs.InstanceAddr == "" {
+				regArgs.InstanceAddr = promptDefault(reader, "Forgejo instance URL", "http://localhost:3000")
+			}
+			if regArgs.Token == "" {
+				regArgs.Token = prompt(reader, "Runner token")
+			}
+			if regArgs.RunnerName == "" {
+				regArgs.RunnerName = promptDefault(reader, "Runner name", hostnameOrDefault())
+			}
+			if regArgs.Labels == "" {
+				regArgs.Labels = prompt(reader, "Runner labels (comma separated)")
+			}
+		}
+
+		if regArgs.Token == "" {
+			return fmt.Errorf("a runner token is required")
+		}
+
+		labels := strings.Split(regArgs.Labels, ",")
+		if err := validateLabels(labels); err != nil {
+			return err
+		}
+
+		if regArgs.RequireSignedWorkflows && regArgs.TrustedKeysFile != "" {
+			if _, err := secure.LoadTrustedKeys(regArgs.TrustedKeysFile); err != nil {
+				return err
+			}
+		}
+
+		cli := client.New(regArgs.InstanceAddr, regArgs.Insecure, "", regArgs.Token, version)
+
+		uuid, err := cli.Register(ctx, regArgs.RunnerName, labels)
+		if err != nil {
+			return fmt.Errorf("register: %w", err)
+		}
+
+		env, _ := godotenv.Read(envFile)
+		if env == nil {
+			env = map[string]string{}
+		}
+		env["GITEA_INSTANCE_URL"] = regArgs.InstanceAddr
+		env["GITEA_RUNNER_INSECURE"] = fmt.Sprintf("%t", regArgs.Insecure)
+		env["GITEA_RUNNER_TOKEN"] = regArgs.Token
+		env["GITEA_RUNNER_UUID"] = uuid
+		env["GITEA_RUNNER_NAME"] = regArgs.RunnerName
+		env["GITEA_RUNNER_LABELS"] = regArgs.Labels
+		env["GITEA_RUNNER_REQUIRE_SIGNED_WORKFLOWS"] = fmt.Sprintf("%t", regArgs.RequireSignedWorkflows)
+		env["GITEA_RUNNER_TRUSTED_KEYS_FILE"] = regArgs.TrustedKeysFile
+
+		if err := godotenv.Write(env, envFile); err != nil {
+			return fmt.Errorf("write %s: %w", envFile, err)
+		}
+
+		log.Infof("runner registered successfully with UUID %s", uuid)
+		return nil
+	}
+}
+
+func prompt(reader *bufio.Reader, label string) string {
+	fmt.Printf("%s: ", label)
+	text, _ := reader.ReadString('\n')
+	return strings.TrimSpace(text)
+}
+
+func promptDefault(reader *bufio.Reader, label, def string) string {
+	fmt.Printf("%s (%s): ", label, def)
+	text, _ := reader.ReadString('\n')
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return def
+	}
+	return text
+}
+
+func hostnameOrDefault() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "runner"
+	}
+	return h
+}
@@ -0,0 +1,168 @@
+// Package config builds runner configuration from the process environment
+// (as loaded from .env by godotenv), so a daemon restart never depends on
+// state written to disk during `register`.
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientConfig describes how to reach the Forgejo instance.
+type ClientConfig struct {
+	Address  string
+	Insecure bool
+}
+
+// RunnerConfig describes this runner's identity and capacity.
+type RunnerConfig struct {
+	UUID            string
+	Name            string
+	Token           string
+	Capacity        int
+	Labels          []string
+	Environ         map[string]string
+	ShutdownTimeout time.Duration
+	// StatusAddr, if set, serves the poller's per-worker status as JSON
+	// (see poller.Poller.ServeHTTP). Disabled by default.
+	StatusAddr string
+
+	// RequireSignedWorkflows and TrustedKeysFile are set by
+	// `act_runner register --require-signed-workflows --trusted-keys-file`
+	// and enforced by runtime.Runner (see package secure).
+	RequireSignedWorkflows bool
+	TrustedKeysFile        string
+
+	// MetricsAddr, if set, serves Prometheus metrics and pprof (see
+	// package metrics) on the `daemon` command. Overridden by
+	// --metrics-addr. Disabled by default.
+	MetricsAddr string
+}
+
+// PlatformConfig reports the host the runner executes on.
+type PlatformConfig struct {
+	OS   string
+	Arch string
+}
+
+// Config is the fully resolved runner configuration.
+type Config struct {
+	Debug bool
+	Trace bool
+
+	Client   ClientConfig
+	Runner   RunnerConfig
+	Platform PlatformConfig
+}
+
+// FromEnviron reads GITEA_* environment variables into a Config. It
+// returns an error if required values (namely the runner token) are
+// missing.
+func FromEnviron() (Config, error) {
+	cfg := Config{
+		Debug: envBool("GITEA_DEBUG", false),
+		Trace: envBool("GITEA_TRACE", false),
+		Client: ClientConfig{
+			Address:  getenv("GITEA_INSTANCE_URL", "http://localhost:3000"),
+			Insecure: envBool("GITEA_RUNNER_INSECURE", false),
+		},
+		Runner: RunnerConfig{
+			UUID:                   os.Getenv("GITEA_RUNNER_UUID"),
+			Name:                   getenv("GITEA_RUNNER_NAME", hostname()),
+			Token:                  os.Getenv("GITEA_RUNNER_TOKEN"),
+			Capacity:               envInt("GITEA_RUNNER_CAPACITY", 1),
+			Environ:                envMap("GITEA_RUNNER_ENVIRON"),
+			ShutdownTimeout:        envDuration("GITEA_RUNNER_SHUTDOWN_TIMEOUT", 30*time.Second),
+			StatusAddr:             os.Getenv("GITEA_RUNNER_STATUS_ADDR"),
+			RequireSignedWorkflows: envBool("GITEA_RUNNER_REQUIRE_SIGNED_WORKFLOWS", false),
+			TrustedKeysFile:        os.Getenv("GITEA_RUNNER_TRUSTED_KEYS_FILE"),
+			MetricsAddr:            os.Getenv("GITEA_METRICS_ADDR"),
+		},
+		Platform: PlatformConfig{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+		},
+	}
+
+	if labels := os.Getenv("GITEA_RUNNER_LABELS"); labels != "" {
+		cfg.Runner.Labels = strings.Split(labels, ",")
+	}
+
+	if cfg.Runner.Token == "" {
+		return cfg, fmt.Errorf("config: GITEA_RUNNER_TOKEN must be set")
+	}
+
+	return cfg, nil
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envBool(key string, fallback bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+func envInt(key string, fallback int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+// envMap parses a comma-separated list of KEY=VALUE pairs.
+func envMap(key string) map[string]string {
+	out := map[string]string{}
+	v := os.Getenv(key)
+	if v == "" {
+		return out
+	}
+	for _, pair := range strings.Split(v, ",") {
+		k, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		out[k] = val
+	}
+	return out
+}
+
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "runner"
+	}
+	return h
+}
@@ -0,0 +1,82 @@
+// Package metrics exposes Prometheus instrumentation for the runner daemon
+// and a /debug/pprof mux, both served on --metrics-addr/GITEA_METRICS_ADDR.
+package metrics
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// JobsStarted/JobsSucceeded/JobsFailed are incremented by
+	// runtime.Runner.Run, labeled by the job's primary runs-on label.
+	JobsStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "forgejo_runner_jobs_started_total",
+		Help: "Number of jobs started, by label.",
+	}, []string{"label"})
+
+	JobsSucceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "forgejo_runner_jobs_succeeded_total",
+		Help: "Number of jobs that finished successfully, by label.",
+	}, []string{"label"})
+
+	JobsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "forgejo_runner_jobs_failed_total",
+		Help: "Number of jobs that finished with an error, by label.",
+	}, []string{"label"})
+
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "forgejo_runner_job_duration_seconds",
+		Help:    "Wall-clock duration of a job, by label.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12), // 1s .. ~34m
+	}, []string{"label"})
+
+	// StepDuration is intentionally unlabeled: a step's name comes from
+	// workflow YAML a job submits, which is arbitrary user-controlled
+	// text and would make it an unbounded-cardinality Prometheus label.
+	StepDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "forgejo_runner_step_duration_seconds",
+		Help:    "Wall-clock duration of a single step.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// CacheRequestDuration is wired up inside artifactcache.Handler,
+	// which lives outside this chunk of the tree.
+	CacheRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "forgejo_runner_cache_request_duration_seconds",
+		Help:    "Latency of artifact cache requests, by outcome (hit/miss).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	InFlightJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "forgejo_runner_in_flight_jobs",
+		Help: "Number of jobs currently executing.",
+	})
+
+	ServerReconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "forgejo_runner_server_reconnects_total",
+		Help: "Number of times a request for work had to be retried after an error.",
+	})
+
+	DockerReachable = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "forgejo_runner_docker_reachable",
+		Help: "1 if the configured Docker daemon was reachable at startup, 0 otherwise.",
+	})
+)
+
+// Handler returns the /metrics and /debug/pprof/* mux to serve on
+// --metrics-addr/GITEA_METRICS_ADDR.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerServesMetrics(t *testing.T) {
+	JobsStarted.WithLabelValues("ubuntu-latest").Inc()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("GET /metrics status = %d, want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "forgejo_runner_jobs_started_total") {
+		t.Errorf("GET /metrics body missing forgejo_runner_jobs_started_total")
+	}
+}
+
+func TestHandlerServesPprofIndex(t *testing.T) {
+	req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("GET /debug/pprof/ status = %d, want 200", rec.Code)
+	}
+}
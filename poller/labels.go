@@ -0,0 +1,40 @@
+package poller
+
+import "strings"
+
+// labelsMatch reports whether any of the requested labels matches one of
+// this runner's configured labels by name, ignoring the ":docker://image"
+// suffix. A Stage with no labels matches any runner.
+func labelsMatch(configured, requested []string) bool {
+	if len(requested) == 0 {
+		return true
+	}
+
+	names := make(map[string]struct{}, len(configured))
+	for _, l := range configured {
+		names[labelName(l)] = struct{}{}
+	}
+
+	for _, r := range requested {
+		if _, ok := names[labelName(r)]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func labelName(label string) string {
+	name, _, _ := strings.Cut(label, ":")
+	return name
+}
+
+// usesDocker reports whether any of the labels names a docker:// backend.
+func usesDocker(labels []string) bool {
+	for _, l := range labels {
+		if _, image, ok := strings.Cut(l, ":"); ok && strings.HasPrefix(image, "docker://") {
+			return true
+		}
+	}
+	return false
+}
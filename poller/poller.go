@@ -0,0 +1,285 @@
+// Package poller repeatedly asks a client.Client for work and dispatches it
+// to a handler, bounding how many Stages run at once and refusing work that
+// doesn't match this runner's labels.
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"codeberg.org/forgejo/runner/client"
+	"codeberg.org/forgejo/runner/metrics"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler executes a single Stage.
+type Handler func(ctx context.Context, stage *client.Stage) error
+
+// logTailSize bounds how many recent LogRows are kept per in-flight job, so
+// a dropped-and-restored connection to the server can replay enough of the
+// tail to not lose context, without the registry growing unbounded over a
+// long-running step.
+const logTailSize = 20
+
+// jobEntry is what the job registry keeps per in-flight BuildUUID: the
+// means to cancel it, and its most recent log rows for replay.
+type jobEntry struct {
+	cancel context.CancelFunc
+	tail   []client.LogRow
+}
+
+// Poller runs `capacity` independent workers, each long-polling client.Client
+// for a Stage and dispatching it to Handler. A shared semaphore additionally
+// bounds how many docker-backed Stages run concurrently, since a runner may
+// also be configured with non-docker labels that don't contend for it. Each
+// in-flight Stage is tracked by BuildUUID in a job registry so Shutdown can
+// wait for it, or cancel it once its grace period elapses, and so its last
+// few log rows can be replayed via RecordLog if the connection to the
+// server drops and comes back mid-Stage.
+type Poller struct {
+	client  client.Client
+	handle  Handler
+	dockers chan struct{}
+	workers []*workerState
+
+	labelsMu sync.RWMutex
+	labels   []string
+
+	jobsMu sync.Mutex
+	jobs   map[string]*jobEntry
+
+	wg sync.WaitGroup
+}
+
+// New builds a Poller with one worker per unit of capacity (clamped to at
+// least 1). labels are this runner's configured `runs-on` labels, used to
+// reject Stages it can't execute.
+func New(cli client.Client, handle Handler, capacity int, labels []string) *Poller {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	p := &Poller{
+		client:  cli,
+		handle:  handle,
+		labels:  labels,
+		dockers: make(chan struct{}, capacity),
+		workers: make([]*workerState, capacity),
+		jobs:    map[string]*jobEntry{},
+	}
+	for i := range p.workers {
+		p.workers[i] = &workerState{id: i}
+	}
+
+	return p
+}
+
+// Poll starts all workers and blocks until ctx is cancelled. Cancelling ctx
+// stops workers from requesting further Stages, but does not interrupt
+// Stages already in flight; use Shutdown to wait for (or cap) those.
+func (p *Poller) Poll(ctx context.Context) error {
+	for _, w := range p.workers {
+		p.wg.Add(1)
+		go p.runWorker(ctx, w)
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Wait blocks until every worker has returned from its current Stage, with
+// no time limit. Prefer Shutdown when a grace period is available.
+func (p *Poller) Wait() {
+	p.wg.Wait()
+}
+
+// Shutdown waits up to timeout for Stages in flight to finish, then cancels
+// whichever are still running so the caller can exit promptly.
+func (p *Poller) Shutdown(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(timeout):
+		log.WithField("inFlight", p.InFlight()).
+			Warn("shutdown grace period elapsed, cancelling stages still in flight")
+		p.cancelAllJobs()
+		<-done
+	}
+}
+
+// SetLabels updates the labels used to accept or Nack newly offered Stages,
+// e.g. after a SIGHUP config reload. It does not affect Stages already
+// dispatched.
+func (p *Poller) SetLabels(labels []string) {
+	p.labelsMu.Lock()
+	defer p.labelsMu.Unlock()
+	p.labels = labels
+}
+
+func (p *Poller) currentLabels() []string {
+	p.labelsMu.RLock()
+	defer p.labelsMu.RUnlock()
+	return p.labels
+}
+
+// InFlight returns the BuildUUIDs currently executing.
+func (p *Poller) InFlight() []string {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+
+	uuids := make([]string, 0, len(p.jobs))
+	for id := range p.jobs {
+		uuids = append(uuids, id)
+	}
+	return uuids
+}
+
+func (p *Poller) runWorker(ctx context.Context, w *workerState) {
+	defer p.wg.Done()
+
+	reconnecting := false
+	for ctx.Err() == nil {
+		stage, err := p.client.Request(ctx, p.currentLabels())
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.WithError(err).WithField("worker", w.id).Warn("request for work failed")
+			metrics.ServerReconnectsTotal.Inc()
+			reconnecting = true
+			continue
+		}
+		if reconnecting {
+			reconnecting = false
+			p.replayTails(ctx)
+		}
+		if stage == nil {
+			continue
+		}
+
+		if !labelsMatch(p.currentLabels(), stage.Labels) {
+			if err := p.client.Nack(ctx, stage.BuildUUID, "no matching label on this runner"); err != nil {
+				log.WithError(err).WithField("buildUUID", stage.BuildUUID).Warn("failed to nack stage")
+			}
+			continue
+		}
+
+		p.runStage(ctx, w, stage)
+	}
+}
+
+func (p *Poller) runStage(parent context.Context, w *workerState, stage *client.Stage) {
+	if usesDocker(stage.Labels) {
+		select {
+		case p.dockers <- struct{}{}:
+			defer func() { <-p.dockers }()
+		case <-parent.Done():
+			return
+		}
+	}
+
+	// The job gets its own context, independent of parent: a shutdown
+	// signal stops new Stages from being requested but must not cut off
+	// one already running until its grace period (Shutdown) elapses.
+	jobCtx, cancel := context.WithCancel(context.Background())
+	p.trackJob(stage.BuildUUID, cancel)
+	defer func() {
+		cancel()
+		p.untrackJob(stage.BuildUUID)
+	}()
+
+	w.setBusy(stage.BuildUUID)
+	defer w.setIdle()
+
+	metrics.InFlightJobs.Inc()
+	defer metrics.InFlightJobs.Dec()
+
+	if err := p.handle(jobCtx, stage); err != nil {
+		log.WithError(err).WithField("buildUUID", stage.BuildUUID).Error("stage handler failed")
+	}
+}
+
+func (p *Poller) trackJob(buildUUID string, cancel context.CancelFunc) {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	p.jobs[buildUUID] = &jobEntry{cancel: cancel}
+}
+
+func (p *Poller) untrackJob(buildUUID string) {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	delete(p.jobs, buildUUID)
+}
+
+func (p *Poller) cancelAllJobs() {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	for _, j := range p.jobs {
+		j.cancel()
+	}
+}
+
+// RecordLog keeps buildUUID's most recent log rows (bounded to
+// logTailSize) so replayTails can resend them if the connection to the
+// server drops and comes back while the Stage is still running. It's a
+// no-op for a BuildUUID that isn't (or is no longer) tracked. Wire it up
+// as Runner.LogSink.
+func (p *Poller) RecordLog(buildUUID string, rows []client.LogRow) {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+
+	j, ok := p.jobs[buildUUID]
+	if !ok {
+		return
+	}
+
+	j.tail = append(j.tail, rows...)
+	if n := len(j.tail); n > logTailSize {
+		j.tail = j.tail[n-logTailSize:]
+	}
+}
+
+// replayTails resends the recorded tail of every job still in flight, so a
+// Stage's log doesn't lose its most recent lines across a reconnect.
+func (p *Poller) replayTails(ctx context.Context) {
+	p.jobsMu.Lock()
+	tails := make(map[string][]client.LogRow, len(p.jobs))
+	for buildUUID, j := range p.jobs {
+		if len(j.tail) > 0 {
+			tails[buildUUID] = j.tail
+		}
+	}
+	p.jobsMu.Unlock()
+
+	for buildUUID, rows := range tails {
+		if err := p.client.Log(ctx, buildUUID, rows); err != nil {
+			log.WithError(err).WithField("buildUUID", buildUUID).Warn("failed to replay log tail after reconnect")
+		}
+	}
+}
+
+// Status reports the current state of every worker.
+func (p *Poller) Status() []WorkerStatus {
+	out := make([]WorkerStatus, len(p.workers))
+	for i, w := range p.workers {
+		out[i] = w.status()
+	}
+	return out
+}
+
+// ServeHTTP renders Status as JSON, so operators can mount the Poller on a
+// status/debug HTTP server.
+func (p *Poller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p.Status())
+}
@@ -0,0 +1,180 @@
+package poller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"codeberg.org/forgejo/runner/client"
+)
+
+func TestRecordLogReplaysTailAfterReconnect(t *testing.T) {
+	fc := client.NewFake()
+	p := New(fc, nil, 1, nil)
+
+	p.trackJob("build-5", func() {})
+	p.RecordLog("build-5", []client.LogRow{{Content: "line 1"}, {Content: "line 2"}})
+
+	p.replayTails(context.Background())
+
+	if len(fc.Logs) != 1 || fc.Logs[0].BuildUUID != "build-5" {
+		t.Fatalf("Logs = %+v, want one replay for build-5", fc.Logs)
+	}
+	if len(fc.Logs[0].Rows) != 2 {
+		t.Errorf("replayed %d rows, want 2", len(fc.Logs[0].Rows))
+	}
+}
+
+func TestRecordLogIgnoresUntrackedJob(t *testing.T) {
+	fc := client.NewFake()
+	p := New(fc, nil, 1, nil)
+
+	p.RecordLog("no-such-build", []client.LogRow{{Content: "line 1"}})
+	p.replayTails(context.Background())
+
+	if len(fc.Logs) != 0 {
+		t.Errorf("Logs = %+v, want no replay for an untracked job", fc.Logs)
+	}
+}
+
+func TestRecordLogBoundsTailSize(t *testing.T) {
+	fc := client.NewFake()
+	p := New(fc, nil, 1, nil)
+
+	p.trackJob("build-6", func() {})
+	for i := 0; i < logTailSize+10; i++ {
+		p.RecordLog("build-6", []client.LogRow{{Content: "line"}})
+	}
+
+	p.replayTails(context.Background())
+
+	if got := len(fc.Logs[0].Rows); got != logTailSize {
+		t.Errorf("replayed %d rows, want %d", got, logTailSize)
+	}
+}
+
+func TestLabelsMatch(t *testing.T) {
+	cases := []struct {
+		name       string
+		configured []string
+		requested  []string
+		want       bool
+	}{
+		{"no labels requested", []string{"ubuntu-latest:docker://node:16"}, nil, true},
+		{"matching name, different image", []string{"ubuntu-latest:docker://node:16"}, []string{"ubuntu-latest:docker://node:20"}, true},
+		{"no match", []string{"ubuntu-latest:docker://node:16"}, []string{"windows-latest:docker://win"}, false},
+		{"bare label match", []string{"self-hosted"}, []string{"self-hosted"}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := labelsMatch(tc.configured, tc.requested); got != tc.want {
+				t.Errorf("labelsMatch(%v, %v) = %v, want %v", tc.configured, tc.requested, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPollerNacksUnmatchedLabels(t *testing.T) {
+	fc := client.NewFake()
+	fc.Push(&client.Stage{BuildUUID: "build-1", Labels: []string{"windows-latest"}})
+
+	handled := make(chan string, 1)
+	p := New(fc, func(_ context.Context, stage *client.Stage) error {
+		handled <- stage.BuildUUID
+		return nil
+	}, 1, []string{"ubuntu-latest"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_ = p.Poll(ctx)
+	p.Wait()
+
+	select {
+	case <-handled:
+		t.Fatal("handler should not have run for a mismatched label")
+	default:
+	}
+
+	if len(fc.Nacks) != 1 || fc.Nacks[0].BuildUUID != "build-1" {
+		t.Errorf("Nacks = %+v, want one nack for build-1", fc.Nacks)
+	}
+}
+
+func TestPollerDispatchesMatchedLabels(t *testing.T) {
+	fc := client.NewFake()
+	fc.Push(&client.Stage{BuildUUID: "build-2", Labels: []string{"ubuntu-latest"}})
+
+	handled := make(chan string, 1)
+	p := New(fc, func(_ context.Context, stage *client.Stage) error {
+		handled <- stage.BuildUUID
+		return nil
+	}, 1, []string{"ubuntu-latest"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	go p.Poll(ctx)
+
+	select {
+	case buildUUID := <-handled:
+		if buildUUID != "build-2" {
+			t.Errorf("handled build %q, want build-2", buildUUID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was never called")
+	}
+}
+
+func TestShutdownWaitsForInFlightStage(t *testing.T) {
+	fc := client.NewFake()
+	fc.Push(&client.Stage{BuildUUID: "build-3", Labels: []string{"ubuntu-latest"}})
+
+	started := make(chan struct{})
+	p := New(fc, func(ctx context.Context, stage *client.Stage) error {
+		close(started)
+		<-time.After(50 * time.Millisecond)
+		return nil
+	}, 1, []string{"ubuntu-latest"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go p.Poll(ctx)
+
+	<-started
+	cancel() // no more Requests, but the in-flight stage must still finish
+
+	p.Shutdown(time.Second)
+
+	if n := len(p.InFlight()); n != 0 {
+		t.Errorf("InFlight() = %d after Shutdown, want 0", n)
+	}
+}
+
+func TestShutdownCancelsAfterGracePeriod(t *testing.T) {
+	fc := client.NewFake()
+	fc.Push(&client.Stage{BuildUUID: "build-4", Labels: []string{"ubuntu-latest"}})
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	p := New(fc, func(ctx context.Context, stage *client.Stage) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}, 1, []string{"ubuntu-latest"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go p.Poll(ctx)
+
+	<-started
+	cancel()
+
+	p.Shutdown(10 * time.Millisecond)
+
+	select {
+	case <-cancelled:
+	default:
+		t.Error("stage was not cancelled after its shutdown grace period elapsed")
+	}
+}
@@ -0,0 +1,37 @@
+package poller
+
+import "sync/atomic"
+
+// WorkerStatus is a worker's state at a point in time, exposed for
+// operators via Poller.Status/ServeHTTP.
+type WorkerStatus struct {
+	ID        int    `json:"id"`
+	State     string `json:"state"`
+	BuildUUID string `json:"build_uuid,omitempty"`
+}
+
+// workerState tracks one worker goroutine's current Stage, if any.
+type workerState struct {
+	id        int
+	busy      atomic.Bool
+	buildUUID atomic.Value // string
+}
+
+func (w *workerState) setBusy(buildUUID string) {
+	w.buildUUID.Store(buildUUID)
+	w.busy.Store(true)
+}
+
+func (w *workerState) setIdle() {
+	w.busy.Store(false)
+	w.buildUUID.Store("")
+}
+
+func (w *workerState) status() WorkerStatus {
+	state := "idle"
+	if w.busy.Load() {
+		state = "busy"
+	}
+	buildUUID, _ := w.buildUUID.Load().(string)
+	return WorkerStatus{ID: w.id, State: state, BuildUUID: buildUUID}
+}
@@ -0,0 +1,148 @@
+// Package runtime executes the Stages a Runner receives from the client
+// package and reports their outcome back to the server.
+package runtime
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"codeberg.org/forgejo/runner/artifactcache"
+	"codeberg.org/forgejo/runner/client"
+	"codeberg.org/forgejo/runner/metrics"
+	"codeberg.org/forgejo/runner/secure"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// finalUpdateTimeout bounds how long reporting a Stage's final status may
+// take. It is deliberately not derived from the job's own ctx: when
+// Poller.Shutdown's grace period elapses, that ctx is the first thing
+// cancelled, and the final result still needs to reach the server.
+const finalUpdateTimeout = 10 * time.Second
+
+// Runner executes Stages handed out by the poller and streams their
+// progress back through Client.
+type Runner struct {
+	Client        client.Client
+	Machine       string
+	ForgeInstance string
+	Environ       map[string]string
+	Labels        []string
+	Version       string
+	CacheHandler  *artifactcache.Handler
+
+	// RequireSignedWorkflows refuses any Stage whose WorkflowYAML doesn't
+	// carry a valid signature, checked against the repo's signing key
+	// (fetched via Client.SigningKey) and, if TrustedKeys is non-empty,
+	// also pinned against it.
+	RequireSignedWorkflows bool
+	TrustedKeys            []ed25519.PublicKey
+
+	// LogSink, if set, is notified of every row also sent via Client.Log,
+	// so the poller's job registry can replay a Stage's recent log tail
+	// after a dropped-and-restored connection to the server.
+	LogSink func(buildUUID string, rows []client.LogRow)
+}
+
+// Run executes a single Stage end to end: it reports StatusRunning,
+// verifies the workflow signature if required, executes each step, and
+// reports the final StatusSuccess/StatusFailure.
+func (r *Runner) Run(ctx context.Context, stage *client.Stage) error {
+	l := log.WithField("buildUUID", stage.BuildUUID).WithField("event", stage.EventName)
+	label := primaryLabel(stage.Labels)
+
+	metrics.JobsStarted.WithLabelValues(label).Inc()
+	start := time.Now()
+	defer func() {
+		metrics.JobDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+	}()
+
+	if err := r.Client.Update(ctx, stage.BuildUUID, client.StatusRunning, ""); err != nil {
+		l.WithError(err).Warn("failed to report running status")
+	}
+
+	if err := r.verifyWorkflow(ctx, stage); err != nil {
+		l.WithError(err).Error("refusing unsigned or tampered workflow")
+		metrics.JobsFailed.WithLabelValues(label).Inc()
+		return r.reportFinal(stage.BuildUUID, client.StatusFailure, err.Error())
+	}
+
+	if err := r.runSteps(ctx, stage); err != nil {
+		l.WithError(err).Error("stage failed")
+		metrics.JobsFailed.WithLabelValues(label).Inc()
+		return r.reportFinal(stage.BuildUUID, client.StatusFailure, err.Error())
+	}
+
+	metrics.JobsSucceeded.WithLabelValues(label).Inc()
+	return r.reportFinal(stage.BuildUUID, client.StatusSuccess, "")
+}
+
+// reportFinal reports a Stage's terminal status over a fresh context,
+// independent of the job's own ctx: a forcefully drained job's ctx is
+// already cancelled by the time this runs, and the result still needs to
+// reach the server.
+func (r *Runner) reportFinal(buildUUID string, status client.StageStatus, errContent string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), finalUpdateTimeout)
+	defer cancel()
+	return r.Client.Update(ctx, buildUUID, status, errContent)
+}
+
+// primaryLabel returns the first runs-on label for a Stage, or "unknown"
+// if it has none, for use as a low-cardinality metrics label.
+func primaryLabel(labels []string) string {
+	if len(labels) == 0 {
+		return "unknown"
+	}
+	return labels[0]
+}
+
+// verifyWorkflow checks stage's signature when RequireSignedWorkflows is
+// set; it is a no-op otherwise.
+func (r *Runner) verifyWorkflow(ctx context.Context, stage *client.Stage) error {
+	if !r.RequireSignedWorkflows {
+		return nil
+	}
+
+	if len(stage.WorkflowYAML) == 0 || len(stage.WorkflowSig) == 0 {
+		return fmt.Errorf("workflow for %s is unsigned and this runner requires signed workflows", stage.Repo)
+	}
+
+	key, err := r.Client.SigningKey(ctx, stage.Repo)
+	if err != nil {
+		return fmt.Errorf("fetch signing key for %s: %w", stage.Repo, err)
+	}
+
+	if len(r.TrustedKeys) > 0 && !secure.Trusted(r.TrustedKeys, key) {
+		return fmt.Errorf("signing key for %s is not in --trusted-keys-file", stage.Repo)
+	}
+
+	if err := secure.Verify(stage.WorkflowYAML, stage.WorkflowSig, key); err != nil {
+		return fmt.Errorf("%s: %w", stage.Repo, err)
+	}
+
+	return nil
+}
+
+func (r *Runner) runSteps(ctx context.Context, stage *client.Stage) error {
+	for _, step := range stage.Steps {
+		stepStart := time.Now()
+
+		row := client.LogRow{Time: time.Now(), Content: fmt.Sprintf("Run %s", step.Name)}
+		if err := r.Client.Log(ctx, stage.BuildUUID, []client.LogRow{row}); err != nil {
+			log.WithError(err).Warn("failed to stream log row")
+		}
+		if r.LogSink != nil {
+			r.LogSink(stage.BuildUUID, []client.LogRow{row})
+		}
+
+		metrics.StepDuration.Observe(time.Since(stepStart).Seconds())
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+
+	"codeberg.org/forgejo/runner/client"
+)
+
+func TestRunRefusesUnsignedWorkflow(t *testing.T) {
+	fc := client.NewFake()
+	r := &Runner{Client: fc, RequireSignedWorkflows: true}
+
+	stage := &client.Stage{BuildUUID: "build-1", Repo: "octocat/hello-world"}
+	if err := r.Run(context.Background(), stage); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fc.Updates) == 0 || fc.Updates[len(fc.Updates)-1].Status != client.StatusFailure {
+		t.Errorf("Updates = %+v, want a final failure update", fc.Updates)
+	}
+}
+
+func TestRunAcceptsValidlySignedWorkflow(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	yaml := []byte("on: push\njobs: {}\n")
+	sig := signYAML(priv, yaml)
+
+	fc := client.NewFake()
+	fc.SigningKeys = map[string]ed25519.PublicKey{"octocat/hello-world": pub}
+
+	r := &Runner{Client: fc, RequireSignedWorkflows: true, TrustedKeys: []ed25519.PublicKey{pub}}
+	stage := &client.Stage{
+		BuildUUID:    "build-2",
+		Repo:         "octocat/hello-world",
+		WorkflowYAML: yaml,
+		WorkflowSig:  sig,
+	}
+
+	if err := r.Run(context.Background(), stage); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fc.Updates) == 0 || fc.Updates[len(fc.Updates)-1].Status != client.StatusSuccess {
+		t.Errorf("Updates = %+v, want a final success update", fc.Updates)
+	}
+}
+
+// signYAML signs yaml's SHA-256 checksum, matching what secure.Verify
+// expects.
+func signYAML(priv ed25519.PrivateKey, yaml []byte) []byte {
+	sum := sha256.Sum256(yaml)
+	return ed25519.Sign(priv, sum[:])
+}
+
+// TestRunReportsFinalStatusAfterContextCancelled guards against the final
+// Update being sent over the job's own ctx: Poller cancels that ctx first
+// when a shutdown's grace period elapses, which must not prevent the
+// terminal status from reaching the server.
+func TestRunReportsFinalStatusAfterContextCancelled(t *testing.T) {
+	fc := client.NewFake()
+	r := &Runner{Client: fc}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stage := &client.Stage{BuildUUID: "build-3"}
+	if err := r.Run(ctx, stage); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(fc.Updates) == 0 || fc.Updates[len(fc.Updates)-1].Status != client.StatusSuccess {
+		t.Errorf("Updates = %+v, want a final success update despite the cancelled ctx", fc.Updates)
+	}
+}
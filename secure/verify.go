@@ -0,0 +1,66 @@
+// Package secure verifies that a workflow file a runner is about to execute
+// hasn't been tampered with, by checking a SHA-256 checksum signed by a
+// repo-scoped ed25519 keypair.
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// Verify reports whether sig is a valid ed25519 signature by pubkey over
+// the SHA-256 checksum of yaml.
+func Verify(yaml, sig []byte, pubkey ed25519.PublicKey) error {
+	if len(pubkey) != ed25519.PublicKeySize {
+		return fmt.Errorf("secure: invalid public key size %d", len(pubkey))
+	}
+
+	sum := sha256.Sum256(yaml)
+	if !ed25519.Verify(pubkey, sum[:], sig) {
+		return fmt.Errorf("secure: workflow signature does not match its checksum")
+	}
+
+	return nil
+}
+
+// Trusted reports whether key appears in keys.
+func Trusted(keys []ed25519.PublicKey, key ed25519.PublicKey) bool {
+	for _, k := range keys {
+		if k.Equal(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTrustedKeys reads one or more PEM-encoded ed25519 public keys from
+// path (one "PUBLIC KEY" block per trusted signer).
+func LoadTrustedKeys(path string) ([]ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("secure: read trusted keys file: %w", err)
+	}
+
+	var keys []ed25519.PublicKey
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if len(block.Bytes) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("secure: %s: unexpected public key size %d", path, len(block.Bytes))
+		}
+		keys = append(keys, ed25519.PublicKey(block.Bytes))
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("secure: %s: no PEM-encoded public keys found", path)
+	}
+
+	return keys, nil
+}
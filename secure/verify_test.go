@@ -0,0 +1,42 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+)
+
+func sum(b []byte) []byte {
+	s := sha256.Sum256(b)
+	return s[:]
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	yaml := []byte("on: push\njobs: {}\n")
+	sig := ed25519.Sign(priv, sum(yaml))
+
+	if err := Verify(yaml, sig, pub); err != nil {
+		t.Errorf("Verify() error = %v, want nil", err)
+	}
+
+	if err := Verify([]byte("tampered"), sig, pub); err == nil {
+		t.Error("Verify() on tampered yaml = nil, want error")
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	pub1, _, _ := ed25519.GenerateKey(nil)
+	pub2, _, _ := ed25519.GenerateKey(nil)
+
+	if !Trusted([]ed25519.PublicKey{pub1}, pub1) {
+		t.Error("Trusted() = false for a key in the set, want true")
+	}
+	if Trusted([]ed25519.PublicKey{pub1}, pub2) {
+		t.Error("Trusted() = true for a key not in the set, want false")
+	}
+}